@@ -0,0 +1,89 @@
+package runestream
+
+import (
+	"bufio"
+	"errors"
+	"io"
+)
+
+// ErrNoUnreadRune is returned by the io.RuneScanner returned from
+// AsRuneScanner when UnreadRune is called without a preceding successful
+// ReadRune.
+var ErrNoUnreadRune = errors.New("runestream: previous operation was not a successful ReadRune")
+
+// runeScanner adapts a RuneStream to io.RuneScanner, using a single-slot
+// SavePoint to support UnreadRune of the single most recently read rune.
+type runeScanner struct {
+	stream    *RuneStream
+	sp        SavePoint
+	canUnread bool
+}
+
+var _ io.RuneScanner = (*runeScanner)(nil)
+
+// AsRuneScanner returns an io.RuneScanner backed by stream, so that stream
+// can be used anywhere an io.RuneReader or io.RuneScanner is expected (e.g.
+// fmt.Fscanf, text/scanner), without giving up stream's position tracking.
+//
+// As with bufio.Reader, only the single most recently read rune can be
+// unread; calling UnreadRune a second time in a row, or before any ReadRune,
+// returns ErrNoUnreadRune.
+func (stream *RuneStream) AsRuneScanner() io.RuneScanner {
+	return &runeScanner{stream: stream}
+}
+
+// ReadRune fulfills io.RuneReader.
+func (rs *runeScanner) ReadRune() (rune, int, error) {
+	if rs.canUnread {
+		rs.stream.Commit()
+		rs.canUnread = false
+	}
+	rs.sp = rs.stream.Save()
+	if !rs.stream.Advance() {
+		return 0, 0, rs.stream.Err()
+	}
+	rs.canUnread = true
+	return rs.stream.Rune(), rs.stream.Size(), nil
+}
+
+// UnreadRune fulfills io.RuneScanner.
+func (rs *runeScanner) UnreadRune() error {
+	if !rs.canUnread {
+		return ErrNoUnreadRune
+	}
+	rs.canUnread = false
+	rs.stream.Restore(rs.sp)
+	return nil
+}
+
+// SplitRunes returns a bufio.SplitFunc that splits input into the runes
+// decoded by dec, analogous to bufio.ScanRunes but for an arbitrary Decoder.
+// A nil dec behaves like UTF8Decoder{}.
+func SplitRunes(dec Decoder) bufio.SplitFunc {
+	dec = Options{Decoder: dec}.decoder()
+	return func(data []byte, atEOF bool) (advance int, token []byte, err error) {
+		if atEOF && len(data) == 0 {
+			return 0, nil, nil
+		}
+		if !dec.FullRune(data) {
+			if !atEOF {
+				return 0, nil, nil
+			}
+			// A dangling partial code unit at end-of-stream: dec.DecodeRune
+			// is documented to assume a full rune is present, and decoders
+			// that index fixed byte offsets (e.g. the fixed-width charset
+			// decoders) would read out of bounds on it. Report it as
+			// ErrInvalidEncoding instead, which ends the scan.
+			return 1, data[:1], ErrInvalidEncoding
+		}
+		_, size := dec.DecodeRune(data)
+		if size <= 0 {
+			skip := -size
+			if skip == 0 {
+				skip = 1
+			}
+			size = skip
+		}
+		return size, data[:size], nil
+	}
+}
@@ -0,0 +1,75 @@
+package runestream
+
+import (
+	"bufio"
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/chronos-tachyon/go-runestream/charset"
+)
+
+func TestRuneScanner_ReadUnread(t *testing.T) {
+	stream := NewRuneStream(strings.NewReader("abc"))
+	rs := stream.AsRuneScanner()
+
+	ch, _, err := rs.ReadRune()
+	if err != nil || ch != 'a' {
+		t.Fatalf("expected ('a', nil), got (%q, %v)", ch, err)
+	}
+	if err := rs.UnreadRune(); err != nil {
+		t.Fatalf("unexpected UnreadRune error: %v", err)
+	}
+	if err := rs.UnreadRune(); err != ErrNoUnreadRune {
+		t.Errorf("expected ErrNoUnreadRune on a second UnreadRune, got %v", err)
+	}
+
+	ch, _, err = rs.ReadRune()
+	if err != nil || ch != 'a' {
+		t.Fatalf("expected to re-read 'a', got (%q, %v)", ch, err)
+	}
+	ch, _, err = rs.ReadRune()
+	if err != nil || ch != 'b' {
+		t.Fatalf("expected 'b', got (%q, %v)", ch, err)
+	}
+}
+
+func TestSplitRunes_UTF8(t *testing.T) {
+	sc := bufio.NewScanner(strings.NewReader("a日b"))
+	sc.Split(SplitRunes(nil))
+
+	var got []string
+	for sc.Scan() {
+		got = append(got, sc.Text())
+	}
+	if err := sc.Err(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	want := []string{"a", "日", "b"}
+	if len(got) != len(want) {
+		t.Fatalf("expected %q, got %q", want, got)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("[%d] expected %q, got %q", i, want[i], got[i])
+		}
+	}
+}
+
+func TestSplitRunes_TruncatedInputAtEOF(t *testing.T) {
+	// A dangling lone low byte of a UTF-16LE code unit at EOF must not
+	// panic; SplitFunc should report it as an invalid encoding instead.
+	sc := bufio.NewScanner(bytes.NewReader([]byte{0x41, 0x00, 0x42}))
+	sc.Split(SplitRunes(charset.UTF16LEDecoder{}))
+
+	var tokens [][]byte
+	for sc.Scan() {
+		tokens = append(tokens, append([]byte{}, sc.Bytes()...))
+	}
+	if len(tokens) != 1 || !bytes.Equal(tokens[0], []byte{0x41, 0x00}) {
+		t.Errorf("expected a single full rune token, got %v", tokens)
+	}
+	if sc.Err() != ErrInvalidEncoding {
+		t.Errorf("expected ErrInvalidEncoding, got %v", sc.Err())
+	}
+}
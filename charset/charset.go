@@ -0,0 +1,58 @@
+// Package charset provides Decoder implementations for common non-UTF-8
+// text encodings, for use with runestream.Options.Decoder.
+package charset
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Decoder mirrors the method set of runestream.Decoder.  It is declared here,
+// rather than imported, so that this package does not need to import
+// runestream; runestream imports charset (to implement
+// NewRuneStreamDetect), and the reverse import would create a cycle.  Any
+// value satisfying this interface also satisfies runestream.Decoder.
+type Decoder interface {
+	// Name returns the name of the charset.
+	Name() string
+
+	// FullRune returns true iff p contains all the bytes needed to decode
+	// the next rune.
+	FullRune(p []byte) bool
+
+	// DecodeRune returns the next rune in p, and the number of bytes used
+	// to represent it.  An invalid byte sequence is reported by returning
+	// (utf8.RuneError, n) with n <= 0; RuneStream resynchronizes by
+	// skipping -n bytes (or 1 byte, if n == 0).  A Decoder whose code
+	// unit is wider than one byte, such as UTF-16, should report
+	// -(unit size) so that resynchronization stays aligned to a unit
+	// boundary instead of desyncing mid-stream.
+	DecodeRune(p []byte) (rune, int)
+}
+
+// registry maps lowercase IANA charset names and common aliases to the
+// Decoder that implements them.
+var registry = map[string]Decoder{
+	"utf-8":        UTF8Decoder{},
+	"utf8":         UTF8Decoder{},
+	"iso-8859-1":   Latin1Decoder{},
+	"latin1":       Latin1Decoder{},
+	"latin-1":      Latin1Decoder{},
+	"windows-1252": Windows1252Decoder{},
+	"cp1252":       Windows1252Decoder{},
+	"utf-16le":     UTF16LEDecoder{},
+	"utf-16be":     UTF16BEDecoder{},
+	"utf-32le":     UTF32LEDecoder{},
+	"utf-32be":     UTF32BEDecoder{},
+}
+
+// ByName returns the Decoder registered under name, matched case-
+// insensitively against IANA charset names and their common aliases.  It
+// returns an error if name is not recognized.
+func ByName(name string) (Decoder, error) {
+	dec, ok := registry[strings.ToLower(name)]
+	if !ok {
+		return nil, fmt.Errorf("charset: unrecognized charset name %q", name)
+	}
+	return dec, nil
+}
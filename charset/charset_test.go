@@ -0,0 +1,104 @@
+package charset
+
+import (
+	"testing"
+	"unicode/utf8"
+)
+
+func TestByName(t *testing.T) {
+	if _, err := ByName("UTF-8"); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+	if _, err := ByName("latin-1"); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+	if _, err := ByName("bogus-charset"); err == nil {
+		t.Errorf("expected an error for an unrecognized charset name")
+	}
+}
+
+func TestLatin1Decoder(t *testing.T) {
+	dec := Latin1Decoder{}
+	if !dec.FullRune([]byte{0xE9}) {
+		t.Errorf("expected a single byte to be a full rune")
+	}
+	ch, size := dec.DecodeRune([]byte{0xE9})
+	if ch != 0xE9 || size != 1 {
+		t.Errorf("expected (0xE9, 1), got (%#x, %d)", ch, size)
+	}
+}
+
+func TestWindows1252Decoder(t *testing.T) {
+	dec := Windows1252Decoder{}
+
+	// 0x80 is the Euro sign in Windows-1252, unlike Latin-1.
+	ch, size := dec.DecodeRune([]byte{0x80})
+	if ch != 0x20AC || size != 1 {
+		t.Errorf("expected (0x20AC, 1), got (%#x, %d)", ch, size)
+	}
+
+	// Outside the 0x80-0x9F range, it agrees with Latin-1.
+	ch, size = dec.DecodeRune([]byte{0x41})
+	if ch != 'A' || size != 1 {
+		t.Errorf("expected ('A', 1), got (%q, %d)", ch, size)
+	}
+}
+
+func TestUTF16Decoders(t *testing.T) {
+	// U+1F600 GRINNING FACE, encoded as a surrogate pair.
+	le := []byte{0x3D, 0xD8, 0x00, 0xDE}
+	be := []byte{0xD8, 0x3D, 0xDE, 0x00}
+
+	ch, size := UTF16LEDecoder{}.DecodeRune(le)
+	if ch != 0x1F600 || size != 4 {
+		t.Errorf("LE: expected (0x1F600, 4), got (%#x, %d)", ch, size)
+	}
+	ch, size = UTF16BEDecoder{}.DecodeRune(be)
+	if ch != 0x1F600 || size != 4 {
+		t.Errorf("BE: expected (0x1F600, 4), got (%#x, %d)", ch, size)
+	}
+
+	// A lone high surrogate is invalid and must report a skip width of a
+	// whole code unit, per the Decoder.DecodeRune contract.
+	ch, size = UTF16LEDecoder{}.DecodeRune([]byte{0x3D, 0xD8})
+	if ch != utf8.RuneError || size != -2 {
+		t.Errorf("expected (RuneError, -2) for a lone high surrogate, got (%#x, %d)", ch, size)
+	}
+
+	if !(UTF16LEDecoder{}).FullRune([]byte{0x41, 0x00}) {
+		t.Errorf("expected a non-surrogate code unit to be a full rune")
+	}
+	if (UTF16LEDecoder{}).FullRune(le[:2]) {
+		t.Errorf("expected a lone high surrogate to not be a full rune")
+	}
+}
+
+func TestUTF32Decoders(t *testing.T) {
+	le := []byte{0x00, 0xF6, 0x01, 0x00} // U+1F600
+	be := []byte{0x00, 0x01, 0xF6, 0x00}
+
+	ch, size := UTF32LEDecoder{}.DecodeRune(le)
+	if ch != 0x1F600 || size != 4 {
+		t.Errorf("LE: expected (0x1F600, 4), got (%#x, %d)", ch, size)
+	}
+	ch, size = UTF32BEDecoder{}.DecodeRune(be)
+	if ch != 0x1F600 || size != 4 {
+		t.Errorf("BE: expected (0x1F600, 4), got (%#x, %d)", ch, size)
+	}
+	if (UTF32LEDecoder{}).FullRune(le[:3]) {
+		t.Errorf("expected 3 bytes to not be a full UTF-32 rune")
+	}
+
+	// A surrogate half is not a valid Unicode code point and must be
+	// rejected rather than returned as-is.
+	ch, size = UTF32LEDecoder{}.DecodeRune([]byte{0x00, 0xD8, 0x00, 0x00})
+	if ch != utf8.RuneError || size != -4 {
+		t.Errorf("LE: expected (RuneError, -4) for a surrogate half, got (%#x, %d)", ch, size)
+	}
+
+	// A value beyond utf8.MaxRune is likewise invalid.
+	ch, size = UTF32BEDecoder{}.DecodeRune([]byte{0x00, 0x11, 0x00, 0x00})
+	if ch != utf8.RuneError || size != -4 {
+		t.Errorf("BE: expected (RuneError, -4) for a value beyond utf8.MaxRune, got (%#x, %d)", ch, size)
+	}
+}
@@ -0,0 +1,16 @@
+package charset
+
+// Latin1Decoder implements Decoder for ISO-8859-1 (Latin-1), which maps each
+// byte directly onto the Unicode code point of the same value.
+type Latin1Decoder struct{}
+
+var _ Decoder = Latin1Decoder{}
+
+// Name fulfills the Decoder interface.
+func (Latin1Decoder) Name() string { return "iso-8859-1" }
+
+// FullRune fulfills the Decoder interface.
+func (Latin1Decoder) FullRune(p []byte) bool { return len(p) >= 1 }
+
+// DecodeRune fulfills the Decoder interface.
+func (Latin1Decoder) DecodeRune(p []byte) (rune, int) { return rune(p[0]), 1 }
@@ -0,0 +1,77 @@
+package charset
+
+import (
+	"unicode/utf16"
+	"unicode/utf8"
+)
+
+// UTF16LEDecoder implements Decoder for UTF-16LE, including surrogate pairs.
+type UTF16LEDecoder struct{}
+
+var _ Decoder = UTF16LEDecoder{}
+
+// Name fulfills the Decoder interface.
+func (UTF16LEDecoder) Name() string { return "utf-16le" }
+
+// FullRune fulfills the Decoder interface.
+func (UTF16LEDecoder) FullRune(p []byte) bool {
+	return utf16FullRune(p, func(b []byte) uint16 { return uint16(b[0]) | uint16(b[1])<<8 })
+}
+
+// DecodeRune fulfills the Decoder interface.
+func (UTF16LEDecoder) DecodeRune(p []byte) (rune, int) {
+	return utf16DecodeRune(p, func(b []byte) uint16 { return uint16(b[0]) | uint16(b[1])<<8 })
+}
+
+// UTF16BEDecoder implements Decoder for UTF-16BE, including surrogate pairs.
+type UTF16BEDecoder struct{}
+
+var _ Decoder = UTF16BEDecoder{}
+
+// Name fulfills the Decoder interface.
+func (UTF16BEDecoder) Name() string { return "utf-16be" }
+
+// FullRune fulfills the Decoder interface.
+func (UTF16BEDecoder) FullRune(p []byte) bool {
+	return utf16FullRune(p, func(b []byte) uint16 { return uint16(b[0])<<8 | uint16(b[1]) })
+}
+
+// DecodeRune fulfills the Decoder interface.
+func (UTF16BEDecoder) DecodeRune(p []byte) (rune, int) {
+	return utf16DecodeRune(p, func(b []byte) uint16 { return uint16(b[0])<<8 | uint16(b[1]) })
+}
+
+// utf16FullRune reports whether p holds a complete UTF-16 code unit sequence
+// for one rune, given a function that decodes a two-byte unit according to
+// byte order.
+func utf16FullRune(p []byte, unit func([]byte) uint16) bool {
+	if len(p) < 2 {
+		return false
+	}
+	if utf16.IsSurrogate(rune(unit(p))) {
+		return len(p) >= 4
+	}
+	return true
+}
+
+// utf16DecodeRune decodes the rune at the start of p, given a function that
+// decodes a two-byte unit according to byte order.
+//
+// An invalid code unit is reported as (utf8.RuneError, -2): resynchronizing
+// by a whole code unit, rather than a single byte, keeps the rest of the
+// stream aligned to unit boundaries instead of silently decoding garbage.
+func utf16DecodeRune(p []byte, unit func([]byte) uint16) (rune, int) {
+	r0 := rune(unit(p))
+	if !utf16.IsSurrogate(r0) {
+		return r0, 2
+	}
+	if len(p) < 4 {
+		return utf8.RuneError, -2
+	}
+	r1 := rune(unit(p[2:]))
+	r := utf16.DecodeRune(r0, r1)
+	if r == utf8.RuneError {
+		return utf8.RuneError, -2
+	}
+	return r, 4
+}
@@ -0,0 +1,49 @@
+package charset
+
+import "unicode/utf8"
+
+// UTF32LEDecoder implements Decoder for UTF-32LE.
+type UTF32LEDecoder struct{}
+
+var _ Decoder = UTF32LEDecoder{}
+
+// Name fulfills the Decoder interface.
+func (UTF32LEDecoder) Name() string { return "utf-32le" }
+
+// FullRune fulfills the Decoder interface.
+func (UTF32LEDecoder) FullRune(p []byte) bool { return len(p) >= 4 }
+
+// DecodeRune fulfills the Decoder interface.  A value that is a surrogate
+// half or beyond utf8.MaxRune is not a valid Unicode code point, so it is
+// reported as (utf8.RuneError, -4) rather than returned as-is.
+func (UTF32LEDecoder) DecodeRune(p []byte) (rune, int) {
+	v := uint32(p[0]) | uint32(p[1])<<8 | uint32(p[2])<<16 | uint32(p[3])<<24
+	r := rune(v)
+	if !utf8.ValidRune(r) {
+		return utf8.RuneError, -4
+	}
+	return r, 4
+}
+
+// UTF32BEDecoder implements Decoder for UTF-32BE.
+type UTF32BEDecoder struct{}
+
+var _ Decoder = UTF32BEDecoder{}
+
+// Name fulfills the Decoder interface.
+func (UTF32BEDecoder) Name() string { return "utf-32be" }
+
+// FullRune fulfills the Decoder interface.
+func (UTF32BEDecoder) FullRune(p []byte) bool { return len(p) >= 4 }
+
+// DecodeRune fulfills the Decoder interface.  A value that is a surrogate
+// half or beyond utf8.MaxRune is not a valid Unicode code point, so it is
+// reported as (utf8.RuneError, -4) rather than returned as-is.
+func (UTF32BEDecoder) DecodeRune(p []byte) (rune, int) {
+	v := uint32(p[0])<<24 | uint32(p[1])<<16 | uint32(p[2])<<8 | uint32(p[3])
+	r := rune(v)
+	if !utf8.ValidRune(r) {
+		return utf8.RuneError, -4
+	}
+	return r, 4
+}
@@ -0,0 +1,27 @@
+package charset
+
+import "unicode/utf8"
+
+// UTF8Decoder implements Decoder for UTF-8.
+//
+// It mirrors runestream.UTF8Decoder; it is redeclared here so that
+// charset.ByName("utf-8") works without this package needing to import
+// runestream.
+type UTF8Decoder struct{}
+
+var _ Decoder = UTF8Decoder{}
+
+// Name fulfills the Decoder interface.
+func (UTF8Decoder) Name() string { return "utf-8" }
+
+// FullRune fulfills the Decoder interface.
+func (UTF8Decoder) FullRune(p []byte) bool { return utf8.FullRune(p) }
+
+// DecodeRune fulfills the Decoder interface.
+func (UTF8Decoder) DecodeRune(p []byte) (rune, int) {
+	ch, size := utf8.DecodeRune(p)
+	if ch == utf8.RuneError && size <= 1 {
+		return utf8.RuneError, -1
+	}
+	return ch, size
+}
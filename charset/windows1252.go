@@ -0,0 +1,38 @@
+package charset
+
+// windows1252High maps bytes 0x80-0x9F, the range in which Windows-1252
+// diverges from ISO-8859-1, to the Unicode code points they represent.  The
+// five bytes Windows-1252 leaves undefined (0x81, 0x8D, 0x8F, 0x90, 0x9D)
+// are passed through as their Latin-1 equivalents, matching common lenient
+// implementations.
+var windows1252High = [32]rune{
+	0x20AC, 0x0081, 0x201A, 0x0192,
+	0x201E, 0x2026, 0x2020, 0x2021,
+	0x02C6, 0x2030, 0x0160, 0x2039,
+	0x0152, 0x008D, 0x017D, 0x008F,
+	0x0090, 0x2018, 0x2019, 0x201C,
+	0x201D, 0x2022, 0x2013, 0x2014,
+	0x02DC, 0x2122, 0x0161, 0x203A,
+	0x0153, 0x009D, 0x017E, 0x0178,
+}
+
+// Windows1252Decoder implements Decoder for Windows-1252 (cp1252), which
+// agrees with ISO-8859-1 everywhere except the 0x80-0x9F range.
+type Windows1252Decoder struct{}
+
+var _ Decoder = Windows1252Decoder{}
+
+// Name fulfills the Decoder interface.
+func (Windows1252Decoder) Name() string { return "windows-1252" }
+
+// FullRune fulfills the Decoder interface.
+func (Windows1252Decoder) FullRune(p []byte) bool { return len(p) >= 1 }
+
+// DecodeRune fulfills the Decoder interface.
+func (Windows1252Decoder) DecodeRune(p []byte) (rune, int) {
+	b := p[0]
+	if b >= 0x80 && b <= 0x9F {
+		return windows1252High[b-0x80], 1
+	}
+	return rune(b), 1
+}
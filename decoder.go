@@ -9,15 +9,19 @@ type Decoder interface {
 	// Name returns the name of the charset.
 	Name() string
 
-	// Max returns the maximum number of bytes per rune.
-	Max() int
-
 	// FullRune returns true iff p contains all the bytes needed to decode
 	// the next rune.
 	FullRune(p []byte) bool
 
 	// DecodeRune returns the next rune in p, and the number of bytes used
-	// to represent it.
+	// to represent it.  An invalid byte sequence is reported by returning
+	// (utf8.RuneError, n) with n <= 0; RuneStream treats that as a signal
+	// to report ErrInvalidEncoding and resynchronize by skipping -n bytes
+	// (or 1 byte, if n == 0), rather than as a literal U+FFFD in the
+	// source (which a Decoder reports with its true, positive encoded
+	// size).  A Decoder whose code unit is wider than one byte, such as
+	// UTF-16, should report -(unit size) so that resynchronization stays
+	// aligned to a unit boundary instead of desyncing mid-stream.
 	DecodeRune(p []byte) (rune, int)
 }
 
@@ -29,11 +33,14 @@ var _ Decoder = UTF8Decoder{}
 // Name fulfills the Decoder interface.
 func (UTF8Decoder) Name() string { return "utf-8" }
 
-// Max fulfills the Decoder interface.
-func (UTF8Decoder) Max() int { return utf8.UTFMax }
-
 // FullRune fulfills the Decoder interface.
 func (UTF8Decoder) FullRune(p []byte) bool { return utf8.FullRune(p) }
 
 // DecodeRune fulfills the Decoder interface.
-func (UTF8Decoder) DecodeRune(p []byte) (rune, int) { return utf8.DecodeRune(p) }
+func (UTF8Decoder) DecodeRune(p []byte) (rune, int) {
+	ch, size := utf8.DecodeRune(p)
+	if ch == utf8.RuneError && size <= 1 {
+		return utf8.RuneError, -1
+	}
+	return ch, size
+}
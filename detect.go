@@ -0,0 +1,89 @@
+package runestream
+
+import (
+	"bytes"
+	"io"
+	"regexp"
+
+	"github.com/chronos-tachyon/go-runestream/charset"
+)
+
+// sniffLen is the number of leading bytes inspected by NewRuneStreamDetect
+// when looking for a byte order mark or an XML encoding prolog.
+const sniffLen = 1024
+
+var (
+	utf8BOM    = []byte{0xEF, 0xBB, 0xBF}
+	utf16LEBOM = []byte{0xFF, 0xFE}
+	utf16BEBOM = []byte{0xFE, 0xFF}
+	utf32LEBOM = []byte{0xFF, 0xFE, 0x00, 0x00}
+	utf32BEBOM = []byte{0x00, 0x00, 0xFE, 0xFF}
+)
+
+// xmlEncodingRE matches the encoding declaration of an XML prolog, e.g.
+// `<?xml version="1.0" encoding="ISO-8859-1"?>`.
+var xmlEncodingRE = regexp.MustCompile(`(?i)<\?xml\s+version\s*=\s*"[^"]*"\s+encoding\s*=\s*"([^"]+)"`)
+
+// detectBOM inspects the leading bytes of data for a UTF-8, UTF-16, or
+// UTF-32 byte order mark.  It returns the Decoder implied by the BOM and the
+// number of bytes it occupies, or (nil, 0) if no BOM was recognized.
+//
+// The 4-byte UTF-32LE BOM must be checked before the 2-byte UTF-16LE BOM,
+// since the latter is a prefix of the former.
+func detectBOM(data []byte) (Decoder, int) {
+	switch {
+	case bytes.HasPrefix(data, utf32LEBOM):
+		return charset.UTF32LEDecoder{}, len(utf32LEBOM)
+	case bytes.HasPrefix(data, utf32BEBOM):
+		return charset.UTF32BEDecoder{}, len(utf32BEBOM)
+	case bytes.HasPrefix(data, utf8BOM):
+		return UTF8Decoder{}, len(utf8BOM)
+	case bytes.HasPrefix(data, utf16LEBOM):
+		return charset.UTF16LEDecoder{}, len(utf16LEBOM)
+	case bytes.HasPrefix(data, utf16BEBOM):
+		return charset.UTF16BEDecoder{}, len(utf16BEBOM)
+	}
+	return nil, 0
+}
+
+// detectXMLEncoding looks for an XML prolog naming its encoding in the
+// leading bytes of data, returning the Decoder for that encoding if it is
+// recognized.
+func detectXMLEncoding(data []byte) Decoder {
+	m := xmlEncodingRE.FindSubmatch(data)
+	if m == nil {
+		return nil
+	}
+	dec, err := charset.ByName(string(m[1]))
+	if err != nil {
+		return nil
+	}
+	return dec
+}
+
+// NewRuneStreamDetect constructs a new RuneStream, selecting its Decoder
+// automatically by peeking at the leading bytes of r: first for a UTF-8,
+// UTF-16, or UTF-32 byte order mark, then for an XML prolog declaring its
+// encoding (e.g. `<?xml version="1.0" encoding="..."?>`).  If neither is
+// found, it falls back to UTF8Decoder{}.
+//
+// A recognized byte order mark is consumed; an XML prolog is left intact,
+// since it is ordinary document content.  Every other byte of r is still
+// delivered to the returned RuneStream.
+func NewRuneStreamDetect(r io.Reader) *RuneStream {
+	peek := make([]byte, sniffLen)
+	n, _ := io.ReadFull(r, peek)
+	peek = peek[:n]
+
+	dec, skip := detectBOM(peek)
+	if dec == nil {
+		dec = detectXMLEncoding(peek)
+	}
+	if dec == nil {
+		dec = UTF8Decoder{}
+	}
+
+	stream := new(RuneStream)
+	stream.Init(io.MultiReader(bytes.NewReader(peek[skip:]), r), Options{Decoder: dec})
+	return stream
+}
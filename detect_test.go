@@ -0,0 +1,63 @@
+package runestream
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/chronos-tachyon/go-runestream/charset"
+)
+
+func TestNewRuneStreamDetect_BOM(t *testing.T) {
+	data := append(append([]byte{}, utf16LEBOM...), 'A', 0x00, 'B', 0x00)
+	stream := NewRuneStreamDetect(strings.NewReader(string(data)))
+
+	var got []rune
+	for stream.Advance() {
+		got = append(got, stream.Rune())
+	}
+	if stream.Err() == nil {
+		t.Errorf("expected io.EOF, got nil")
+	}
+	if string(got) != "AB" {
+		t.Errorf("expected %q, got %q", "AB", string(got))
+	}
+}
+
+func TestNewRuneStreamDetect_XMLProlog(t *testing.T) {
+	src := `<?xml version="1.0" encoding="ISO-8859-1"?><a>` + "\xe9" + `</a>`
+	stream := NewRuneStreamDetect(strings.NewReader(src))
+
+	var got []rune
+	for stream.Advance() {
+		got = append(got, stream.Rune())
+	}
+	// The prolog itself is left intact in the stream, and 0xE9 should have
+	// decoded as Latin-1 'é' rather than being rejected as invalid UTF-8.
+	if !strings.Contains(string(got), "é") {
+		t.Errorf("expected decoded output to contain %q, got %q", "é", string(got))
+	}
+}
+
+func TestNewRuneStreamDetect_NoBOMFallsBackToUTF8(t *testing.T) {
+	stream := NewRuneStreamDetect(strings.NewReader("hello"))
+	if _, ok := stream.dec.(UTF8Decoder); !ok {
+		t.Errorf("expected UTF8Decoder, got %T", stream.dec)
+	}
+}
+
+func TestDetectXMLEncoding_UnknownCharsetIgnored(t *testing.T) {
+	dec := detectXMLEncoding([]byte(`<?xml version="1.0" encoding="bogus-charset"?>`))
+	if dec != nil {
+		t.Errorf("expected nil for an unrecognized charset, got %v", dec)
+	}
+}
+
+func TestDetectBOM_UTF32LEPreferredOverUTF16LE(t *testing.T) {
+	dec, skip := detectBOM(utf32LEBOM)
+	if _, ok := dec.(charset.UTF32LEDecoder); !ok {
+		t.Errorf("expected UTF32LEDecoder, got %T", dec)
+	}
+	if skip != len(utf32LEBOM) {
+		t.Errorf("expected skip=%d, got %d", len(utf32LEBOM), skip)
+	}
+}
@@ -0,0 +1,31 @@
+package runestream
+
+import "errors"
+
+// ErrInvalidEncoding is passed to Options.ErrorHandler (and recorded in
+// Errors()) when the active Decoder rejects a byte sequence.  RuneStream
+// resynchronizes by skipping past it (one byte, for the default UTF-8
+// decoder; a Decoder-defined number of bytes otherwise, so that a
+// multi-byte code unit format doesn't desync) and resuming decoding from
+// there.
+var ErrInvalidEncoding = errors.New("runestream: invalid encoding")
+
+// Errors returns every error that has been passed to Options.ErrorHandler so
+// far (whether or not an ErrorHandler was actually set), in the order
+// Advance encountered them.  This lets a caller collect a full diagnostics
+// report at the end of a stream instead of bailing out on the first bad
+// byte.
+func (stream *RuneStream) Errors() []error {
+	return stream.errs
+}
+
+// reportError records err (at pos) in Errors() and, if one is set, asks
+// Options.ErrorHandler whether Advance should keep going. If no handler is
+// set, it reports defaultResume instead.
+func (stream *RuneStream) reportError(pos Position, err error, defaultResume bool) bool {
+	stream.errs = append(stream.errs, err)
+	if stream.errorHandler == nil {
+		return defaultResume
+	}
+	return stream.errorHandler(pos, err)
+}
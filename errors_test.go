@@ -0,0 +1,98 @@
+package runestream
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"testing"
+)
+
+func TestRuneStream_InvalidEncodingResyncs(t *testing.T) {
+	// 0xFF is never a valid UTF-8 leading byte.
+	input := []byte{'a', 0xFF, 'b'}
+	var stream RuneStream
+	stream.Init(bytes.NewReader(input), Options{})
+
+	var got []rune
+	for stream.Advance() {
+		got = append(got, stream.Rune())
+		stream.Commit()
+	}
+	if string(got) != "ab" {
+		t.Errorf("expected %q after resync, got %q", "ab", string(got))
+	}
+	if len(stream.Errors()) != 1 || stream.Errors()[0] != ErrInvalidEncoding {
+		t.Errorf("expected a single recorded ErrInvalidEncoding, got %v", stream.Errors())
+	}
+}
+
+func TestRuneStream_ErrorHandlerCanVetoResync(t *testing.T) {
+	input := []byte{'a', 0xFF, 'b'}
+	var stream RuneStream
+	stream.Init(bytes.NewReader(input), Options{
+		ErrorHandler: func(pos Position, err error) bool { return false },
+	})
+
+	var got []rune
+	for stream.Advance() {
+		got = append(got, stream.Rune())
+		stream.Commit()
+	}
+	if string(got) != "a" {
+		t.Errorf("expected the stream to stop at the invalid byte, got %q", string(got))
+	}
+	if stream.Err() != ErrInvalidEncoding {
+		t.Errorf("expected ErrInvalidEncoding, got %v", stream.Err())
+	}
+}
+
+type flakyThenEOFReader struct {
+	failuresLeft int
+	err          error
+}
+
+func (r *flakyThenEOFReader) Read(p []byte) (int, error) {
+	if r.failuresLeft > 0 {
+		r.failuresLeft--
+		return 0, r.err
+	}
+	return 0, io.EOF
+}
+
+func TestRuneStream_NonEOFErrorIsTerminalWithoutHandler(t *testing.T) {
+	boom := errors.New("boom")
+	r := &flakyThenEOFReader{failuresLeft: 1, err: boom}
+	var stream RuneStream
+	stream.Init(r, Options{})
+
+	if stream.Advance() {
+		t.Fatalf("expected Advance to fail immediately")
+	}
+	if stream.Err() != boom {
+		t.Errorf("expected %v, got %v", boom, stream.Err())
+	}
+}
+
+func TestRuneStream_ErrorHandlerCanResumePastTransientError(t *testing.T) {
+	boom := errors.New("boom")
+	r := &flakyThenEOFReader{failuresLeft: 2, err: boom}
+	var stream RuneStream
+	calls := 0
+	stream.Init(r, Options{
+		ErrorHandler: func(pos Position, err error) bool {
+			calls++
+			return err == boom
+		},
+	})
+
+	ok := stream.Advance()
+	if ok {
+		t.Fatalf("expected Advance to eventually fail once the reader reports EOF")
+	}
+	if calls != 2 {
+		t.Errorf("expected the handler to be consulted exactly twice, got %d", calls)
+	}
+	if len(stream.Errors()) != 2 {
+		t.Errorf("expected 2 recorded errors, got %d", len(stream.Errors()))
+	}
+}
@@ -0,0 +1,66 @@
+package runestream
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// goLineDirectiveRE matches a Go-style directive with an explicit column:
+// //line file:line:col. It is tried before goLineNoColDirectiveRE, which
+// would otherwise match "file:line" as the filename and the column as the
+// line.
+var goLineDirectiveRE = regexp.MustCompile(`^//line\s+(.+):(\d+):(\d+)$`)
+
+// goLineNoColDirectiveRE matches a Go-style directive without a column:
+// //line file:line.
+var goLineNoColDirectiveRE = regexp.MustCompile(`^//line\s+(.+):(\d+)$`)
+
+// cLineDirectiveRE matches a C-preprocessor-style line marker, with or
+// without the "line" keyword: #line 10 "file.c" or # 10 "file.c".
+var cLineDirectiveRE = regexp.MustCompile(`^#\s*(?:line\s+)?(\d+)\s+"([^"]*)"`)
+
+// DefaultLineDirective recognizes the common //line and #line directive
+// styles and can be assigned directly to Options.LineDirective.
+//
+// It understands two forms:
+//
+//	//line file:line:col   (Go-style; the column is optional and defaults to 1)
+//	#line line "file"      (C-preprocessor style; also matches "# line \"file\"")
+//
+// Any other text reports ok == false, leaving the stream's position tracking
+// unaffected.
+//
+func DefaultLineDirective(text string) (file string, line, col uint64, ok bool) {
+	text = strings.TrimRight(text, " \t")
+
+	if m := goLineDirectiveRE.FindStringSubmatch(text); m != nil {
+		ln, err := strconv.ParseUint(m[2], 10, 64)
+		if err != nil {
+			return "", 0, 0, false
+		}
+		cl, err := strconv.ParseUint(m[3], 10, 64)
+		if err != nil {
+			return "", 0, 0, false
+		}
+		return m[1], ln, cl, true
+	}
+
+	if m := goLineNoColDirectiveRE.FindStringSubmatch(text); m != nil {
+		ln, err := strconv.ParseUint(m[2], 10, 64)
+		if err != nil {
+			return "", 0, 0, false
+		}
+		return m[1], ln, 1, true
+	}
+
+	if m := cLineDirectiveRE.FindStringSubmatch(text); m != nil {
+		ln, err := strconv.ParseUint(m[1], 10, 64)
+		if err != nil {
+			return "", 0, 0, false
+		}
+		return m[2], ln, 1, true
+	}
+
+	return "", 0, 0, false
+}
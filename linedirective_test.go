@@ -0,0 +1,77 @@
+package runestream
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestDefaultLineDirective_GoStyle(t *testing.T) {
+	file, line, col, ok := DefaultLineDirective("//line foo.go:10:3")
+	if !ok || file != "foo.go" || line != 10 || col != 3 {
+		t.Errorf("expected (foo.go, 10, 3, true), got (%q, %d, %d, %v)", file, line, col, ok)
+	}
+
+	file, line, col, ok = DefaultLineDirective("//line foo.go:10")
+	if !ok || file != "foo.go" || line != 10 || col != 1 {
+		t.Errorf("expected (foo.go, 10, 1, true) with a default column, got (%q, %d, %d, %v)", file, line, col, ok)
+	}
+}
+
+func TestDefaultLineDirective_CStyle(t *testing.T) {
+	file, line, _, ok := DefaultLineDirective(`#line 42 "bar.c"`)
+	if !ok || file != "bar.c" || line != 42 {
+		t.Errorf("expected (bar.c, 42, true), got (%q, %d, %v)", file, line, ok)
+	}
+
+	file, line, _, ok = DefaultLineDirective(`# 42 "bar.c"`)
+	if !ok || file != "bar.c" || line != 42 {
+		t.Errorf("expected (bar.c, 42, true) for the GNU cpp form, got (%q, %d, %v)", file, line, ok)
+	}
+}
+
+func TestDefaultLineDirective_NoMatch(t *testing.T) {
+	if _, _, _, ok := DefaultLineDirective("not a directive"); ok {
+		t.Errorf("expected ok=false for non-directive text")
+	}
+}
+
+func TestRuneStream_LineDirective(t *testing.T) {
+	src := "a\n//line foo.go:10:1\nb\nc\n"
+	var stream RuneStream
+	stream.Init(strings.NewReader(src), Options{LineDirective: DefaultLineDirective})
+
+	var last Position
+	for stream.Advance() {
+		last = stream.Position()
+		stream.Commit()
+	}
+
+	if last.File != "foo.go" {
+		t.Errorf("expected the final Position's File to be %q, got %q", "foo.go", last.File)
+	}
+	// "b\n" then "c\n": two completed lines after the directive takes effect.
+	if last.Line != 11 {
+		t.Errorf("expected Line 11, got %d", last.Line)
+	}
+}
+
+func TestRuneStream_SetPosition(t *testing.T) {
+	var stream RuneStream
+	stream.Init(strings.NewReader("x"), Options{})
+	stream.SetPosition(Position{File: "generated.go", Line: 5, Column: 2})
+
+	stream.Advance()
+	pos := stream.Position()
+	if pos.File != "generated.go" || pos.Line != 5 || pos.Column != 2 {
+		t.Errorf("expected the overridden position, got %+v", pos)
+	}
+}
+
+func TestPosition_StringIncludesFile(t *testing.T) {
+	pos := Position{File: "foo.go", Line: 3, Column: 4, Offset: 10}
+	got := pos.String()
+	want := "foo.go:3:4 (byte offset 10)"
+	if got != want {
+		t.Errorf("expected %q, got %q", want, got)
+	}
+}
@@ -0,0 +1,45 @@
+package runestream
+
+// Options controls the behavior of a RuneStream.
+type Options struct {
+	// Decoder is the charset decoder used to turn bytes from the
+	// underlying io.Reader into runes.  If nil, UTF8Decoder{} is used.
+	Decoder Decoder
+
+	// ErrorHandler, if set, is called once for every error Advance
+	// encounters: with ErrInvalidEncoding when the Decoder rejects a byte
+	// sequence, or with the underlying error for a non-EOF error from the
+	// Reader. Its return value decides what happens next.
+	//
+	// For ErrInvalidEncoding, RuneStream resynchronizes by skipping past
+	// the invalid sequence and continuing by default (whether or not a
+	// handler is set); returning false from the handler overrides that
+	// and ends the stream instead. For a non-EOF Reader error, RuneStream
+	// ends the
+	// stream by default (whether or not a handler is set); returning true
+	// from the handler overrides that and retries the read instead.
+	//
+	// Every error passed to ErrorHandler, whether or not one is set, is
+	// also recorded in RuneStream.Errors().
+	ErrorHandler func(pos Position, err error) (resume bool)
+
+	// LineDirective, if set, is called with the text of each line (the
+	// line terminator excluded) as soon as it has been fully consumed by
+	// Advance.  If it returns ok, the Position of the next rune adopts
+	// the returned file, line and column instead of the physically
+	// tracked ones, the way a //line or #line directive repositions
+	// diagnostics across a preprocessing or code-generation boundary.
+	//
+	// DefaultLineDirective recognizes the common //line and #line styles
+	// and can be assigned here directly.
+	LineDirective func(text string) (file string, line, col uint64, ok bool)
+}
+
+// decoder returns the Decoder that a RuneStream initialized with these
+// Options should use, substituting UTF8Decoder{} for a nil Decoder.
+func (o Options) decoder() Decoder {
+	if o.Decoder != nil {
+		return o.Decoder
+	}
+	return UTF8Decoder{}
+}
@@ -10,6 +10,11 @@ type Position struct {
 	Line       uint64
 	Column     uint64
 	SkipNextLF bool
+
+	// File is the logical file this Position belongs to.  It is empty
+	// unless a //line (or #line) directive has overridden it; see
+	// RuneStream.SetPosition and Options.LineDirective.
+	File string
 }
 
 // MakePosition returns the Position for the start of a text file.
@@ -58,5 +63,8 @@ func (pos *Position) Advance(ch rune, size int) {
 }
 
 func (pos Position) String() string {
+	if pos.File != "" {
+		return fmt.Sprintf("%s:%d:%d (byte offset %d)", pos.File, pos.Line, pos.Column, pos.Offset)
+	}
 	return fmt.Sprintf("line %d column %d (byte offset %d)", pos.Line, pos.Column, pos.Offset)
 }
@@ -7,16 +7,12 @@ import (
 
 const BlockSize = 4096
 
-// savedRune represents a single Unicode character read from a byte stream.
-type savedRune struct {
-	pos   Position
-	value rune
-	size  int
-	err   error
-}
+// maxUncommittedBytes bounds how far buf is allowed to grow between calls to
+// Commit(), guarding against runaway memory use if a caller speculates
+// (Advance()s) without ever committing or rewinding.
+const maxUncommittedBytes = 0x40000000
 
-// RuneStream is an engine for lexing runes from a byte stream.  This version of
-// RuneStream only understands UTF-8.
+// RuneStream is an engine for lexing runes from a byte stream.
 //
 // Using RuneStream is conceptually similar to using ReadRune() / UnreadRune()
 // from bufio.Reader, but RuneStream adds the ability to UnreadRune() an
@@ -79,64 +75,161 @@ type RuneStream struct {
 	// r is the byte stream to read.
 	r io.Reader
 
-	// bb is a byte buffer of (slightly more than) length BlockSize that
-	// will be reused as bytes are read from r.
-	bb []byte
+	// dec is the Decoder used to turn bytes read from r into runes.
+	dec Decoder
+
+	// fastASCII is true iff dec is the built-in UTF8Decoder, in which case
+	// Advance can treat any byte below utf8.RuneSelf as a complete,
+	// self-decoding rune without consulting dec at all.
+	fastASCII bool
+
+	// buf holds every byte read from r since the last Commit(), plus one
+	// trailing sentinel byte at buf[end] equal to utf8.RuneSelf.  Because
+	// that sentinel is never a valid leading ASCII byte, Advance's hot
+	// path can read buf[off] unconditionally, without first checking
+	// off < end.
+	buf []byte
+
+	// off is the offset within buf of the next byte to decode.
+	off uint
 
-	// b is the slice of bb corresponding to the leftover bytes that have
-	// been read from the Reader but not yet processed as runes.
-	b []byte
+	// end is the offset within buf one past the last byte actually read
+	// from r; buf[end] is always the sentinel.
+	end uint
 
-	// pos is the current position within r, i.e. the position of the start
-	// of the next savedRune to be read from r.
+	// pos is the position of the rune at buf[off], i.e. the Position that
+	// the next successful Advance() will report.
 	pos Position
 
-	// buf is the list of savedRunes that have been read from r.
-	buf []savedRune
+	// commitPos is the value pos held as of the last Commit() (or Init),
+	// i.e. the position Rewind() restores.
+	commitPos Position
 
-	// curr is the savedRune in buf that the caller is working on.
-	curr *savedRune
+	// ioErr is the most recent error returned by r.Read.
+	ioErr error
 
-	// gen is the generation number, incremented on each Commit().
-	gen uint
+	// curRune, curSize, curPos and curErr describe the rune (or error)
+	// produced by the most recent Advance().
+	curRune rune
+	curSize int
+	curPos  Position
+	curErr  error
+
+	// haveCur is true once Advance() has been called at least once since
+	// the last Init/Reset/Restore/Rewind/Commit.
+	haveCur bool
+
+	// segActive is true between a StartSegment() and its matching
+	// EndSegment()/Segment().
+	segActive bool
+
+	// segStart is the buf offset at which the active segment began.
+	// Commit() keeps it valid across slides by copying the segment's
+	// consumed prefix into lit before sliding, then resetting segStart
+	// to 0.
+	segStart uint
+
+	// lit accumulates the portion of the active segment that has already
+	// been slid out of buf by one or more Commit() calls.  It is empty
+	// if no Commit() has happened since the matching StartSegment().
+	lit []byte
+
+	// errorHandler, if non-nil, is notified of every error Advance
+	// encounters; see Options.ErrorHandler.
+	errorHandler func(pos Position, err error) (resume bool)
+
+	// errs accumulates every error passed to errorHandler, in order.
+	errs []error
+
+	// lineDirective, if non-nil, is consulted with the text of every
+	// completed line; see Options.LineDirective.
+	lineDirective func(text string) (file string, line, col uint64, ok bool)
+
+	// lineStart is the buf offset at which the current line began.  Like
+	// segStart, Commit() keeps it valid across slides by copying the
+	// line's consumed prefix into lineLit before sliding.
+	lineStart uint
 
-	// spec is the speculative read count, which is an index into buf.
-	spec uint
+	// lineLit accumulates the portion of the current line that has
+	// already been slid out of buf by one or more Commit() calls.
+	lineLit []byte
+
+	// gen is the generation number, incremented on each Commit(); it
+	// guards against Restore()ing a SavePoint captured before a Commit()
+	// slid buf out from under it.
+	gen uint
 }
 
 // SavePoint is a snapshot of a stream position.
 type SavePoint struct {
-	gen  uint
-	spec uint
+	gen       uint
+	off       uint
+	pos       Position
+	lineStart uint
 }
 
-// NewRuneStream constructs a new RuneStream.
+// NewRuneStream constructs a new RuneStream that decodes r as UTF-8.
 func NewRuneStream(r io.Reader) *RuneStream {
-	return &RuneStream{
-		r:   r,
-		bb:  make([]byte, BlockSize+utf8.UTFMax),
-		pos: MakePosition(),
-	}
+	stream := new(RuneStream)
+	stream.Init(r, Options{})
+	return stream
+}
+
+// Init (re-)initializes this RuneStream to read from r using the given
+// Options, as if it had just been constructed.
+//
+// This is useful for saving some GC overhead when prelexing multiple byte
+// streams, since it lets a caller reuse the memory of an existing
+// RuneStream.
+//
+func (stream *RuneStream) Init(r io.Reader, o Options) {
+	dec := o.decoder()
+	stream.r = r
+	stream.dec = dec
+	_, stream.fastASCII = dec.(UTF8Decoder)
+	stream.buf = []byte{utf8.RuneSelf}
+	stream.off = 0
+	stream.end = 0
+	stream.pos = MakePosition()
+	stream.commitPos = stream.pos
+	stream.ioErr = nil
+	stream.haveCur = false
+	stream.segActive = false
+	stream.lit = stream.lit[:0]
+	stream.errorHandler = o.ErrorHandler
+	stream.errs = nil
+	stream.lineDirective = o.LineDirective
+	stream.lineStart = 0
+	stream.lineLit = stream.lineLit[:0]
+	stream.gen++
 }
 
-// Reset returns this RuneStream to the newly-constructed state.
+// Reset returns this RuneStream to the newly-constructed state, reusing the
+// Decoder it was last initialized with.
 //
 // This is useful for saving some GC overhead when prelexing multiple byte
 // streams.
 //
 func (stream *RuneStream) Reset(r io.Reader) {
 	stream.r = r
-	stream.b = nil
+	stream.buf = []byte{utf8.RuneSelf}
+	stream.off = 0
+	stream.end = 0
 	stream.pos.Reset()
-	stream.buf = nil
-	stream.curr = nil
+	stream.commitPos = stream.pos
+	stream.ioErr = nil
+	stream.haveCur = false
+	stream.segActive = false
+	stream.lit = stream.lit[:0]
+	stream.errs = nil
+	stream.lineStart = 0
+	stream.lineLit = stream.lineLit[:0]
 	stream.gen++
-	stream.spec = 0
 }
 
 // Save creates a save point.
 func (stream *RuneStream) Save() SavePoint {
-	return SavePoint{stream.gen, stream.spec}
+	return SavePoint{stream.gen, stream.off, stream.pos, stream.lineStart}
 }
 
 // Restore rewinds the character stream to the given save point.
@@ -144,14 +237,27 @@ func (stream *RuneStream) Restore(sp SavePoint) {
 	if sp.gen != stream.gen {
 		panic("save point is stale")
 	}
-	stream.spec = sp.spec
-	stream.curr = nil
+	stream.off = sp.off
+	stream.pos = sp.pos
+	stream.lineStart = sp.lineStart
+	stream.haveCur = false
 }
 
 // Rewind rewinds the character stream to the last Commit() call.
 func (stream *RuneStream) Rewind() {
-	stream.spec = 0
-	stream.curr = nil
+	stream.off = 0
+	stream.pos = stream.commitPos
+	stream.lineStart = 0
+	stream.haveCur = false
+}
+
+// SetPosition overrides the Position that will be reported for the rune
+// currently at the front of the stream, the next time Advance() succeeds.
+// It is the general-purpose primitive behind Options.LineDirective, and can
+// also be called directly by a caller that has its own notion of where the
+// stream "really" is, such as one resuming a previously-saved lex state.
+func (stream *RuneStream) SetPosition(pos Position) {
+	stream.pos = pos
 }
 
 // Commit tells the RuneStream that the caller will never need to rewind past
@@ -160,82 +266,184 @@ func (stream *RuneStream) Rewind() {
 // Each call to Commit() invalidates all save points.
 //
 func (stream *RuneStream) Commit() {
-	stream.buf = stream.buf[stream.spec:]
+	if stream.segActive {
+		stream.lit = append(stream.lit, stream.buf[stream.segStart:stream.off]...)
+	}
+	if stream.lineDirective != nil {
+		stream.lineLit = append(stream.lineLit, stream.buf[stream.lineStart:stream.off]...)
+	}
+	n := uint(copy(stream.buf, stream.buf[stream.off:]))
+	stream.buf = stream.buf[:n]
+	stream.end = n - 1
+	stream.off = 0
+	stream.commitPos = stream.pos
 	stream.gen++
-	stream.spec = 0
-	stream.curr = nil
+	stream.haveCur = false
+	if stream.segActive {
+		stream.segStart = 0
+	}
+	stream.lineStart = 0
 }
 
-// load reads the next block of runes from the byte stream.
-func (stream *RuneStream) load() {
-	if len(stream.buf) >= 0x40000000 {
+// fill reads another block from r, appending it to buf and replacing the
+// trailing sentinel.  It reports whether any bytes became available; once it
+// returns false, stream.ioErr holds the error (e.g. io.EOF) that stopped it.
+func (stream *RuneStream) fill() bool {
+	if stream.end >= maxUncommittedBytes {
 		panic("too many calls to Advance() without Commit()")
 	}
 
-	x := len(stream.b)
-	y := x + BlockSize
-	copy(stream.bb[0:x], stream.b)
-	n, err := stream.r.Read(stream.bb[x:y])
-	stream.b = stream.bb[0 : x+n]
-	for utf8.FullRune(stream.b) {
-		r, size := utf8.DecodeRune(stream.b)
-		stream.b = stream.b[size:]
-		stream.buf = append(stream.buf, savedRune{
-			pos:   stream.pos,
-			value: r,
-			size:  size,
-		})
-		stream.pos.Advance(r, size)
+	need := stream.end + BlockSize + 1
+	if uint(cap(stream.buf)) < need {
+		grown := make([]byte, stream.end, need)
+		copy(grown, stream.buf[:stream.end])
+		stream.buf = grown
 	}
-	if err != nil {
-		stream.buf = append(stream.buf, savedRune{
-			pos: stream.pos,
-			err: err,
-		})
+	stream.buf = stream.buf[:cap(stream.buf)]
+
+	n, err := stream.r.Read(stream.buf[stream.end : stream.end+BlockSize])
+	stream.end += uint(n)
+	stream.buf[stream.end] = utf8.RuneSelf
+	stream.buf = stream.buf[:stream.end+1]
+	stream.ioErr = err
+	return n > 0
+}
+
+// setCur records the outcome of an Advance() call and, for a successfully
+// decoded rune, advances pos past it.
+func (stream *RuneStream) setCur(ch rune, size int, pos Position, err error) {
+	stream.curRune = ch
+	stream.curSize = size
+	stream.curPos = pos
+	stream.curErr = err
+	stream.haveCur = true
+	if err == nil {
+		stream.pos.Advance(ch, size)
+		if ch == '\n' {
+			stream.endLine(stream.off - uint(size))
+		}
 	}
 }
 
+// endLine is called once per '\n' successfully consumed by Advance().
+// lfOff is the buf offset at which that '\n' begins.  It reports the
+// completed line's text (the terminator excluded) to lineDirective, if one
+// is set, and repositions the stream if the directive recognizes the text.
+func (stream *RuneStream) endLine(lfOff uint) {
+	if stream.lineDirective == nil {
+		stream.lineStart = stream.off
+		stream.lineLit = stream.lineLit[:0]
+		return
+	}
+	text := stream.takeLineText(lfOff)
+	stream.lineStart = stream.off
+	stream.lineLit = stream.lineLit[:0]
+	file, line, col, ok := stream.lineDirective(text)
+	if !ok {
+		return
+	}
+	stream.pos.File = file
+	stream.pos.Line = line
+	stream.pos.Column = col
+}
+
+// takeLineText returns the text of the line ending at lfOff (the buf offset
+// of its '\n'), trimming a preceding '\r' and prepending whatever prefix of
+// the line was already slid out of buf by an intervening Commit().
+func (stream *RuneStream) takeLineText(lfOff uint) string {
+	if lfOff > stream.lineStart && stream.buf[lfOff-1] == '\r' {
+		lfOff--
+	}
+	if len(stream.lineLit) == 0 {
+		return string(stream.buf[stream.lineStart:lfOff])
+	}
+	return string(stream.lineLit) + string(stream.buf[stream.lineStart:lfOff])
+}
+
 // Advance moves forward in the stream, returning true if a new character is
 // available or false if an I/O error (such as io.EOF) was encountered.
 func (stream *RuneStream) Advance() bool {
-	if stream.curr != nil && stream.curr.err != nil {
+	if stream.haveCur && stream.curErr != nil {
 		return false
 	}
-	if stream.spec >= uint(len(stream.buf)) {
-		stream.load()
+
+	for {
+		pos := stream.pos
+
+		if stream.fastASCII {
+			if b := stream.buf[stream.off]; b < utf8.RuneSelf {
+				stream.off++
+				stream.setCur(rune(b), 1, pos, nil)
+				return true
+			}
+		}
+
+		ready := true
+		for !stream.dec.FullRune(stream.buf[stream.off:stream.end]) {
+			if stream.fill() {
+				continue
+			}
+			ready = false
+			break
+		}
+		if !ready {
+			err := stream.ioErr
+			if err != io.EOF && stream.reportError(pos, err, false) {
+				stream.ioErr = nil
+				continue
+			}
+			stream.setCur(0, 0, pos, err)
+			return false
+		}
+
+		ch, size := stream.dec.DecodeRune(stream.buf[stream.off:stream.end])
+		if size <= 0 {
+			skip := -size
+			if skip == 0 {
+				skip = 1
+			}
+			if !stream.reportError(pos, ErrInvalidEncoding, true) {
+				stream.setCur(0, 0, pos, ErrInvalidEncoding)
+				return false
+			}
+			stream.off += uint(skip)
+			stream.pos.Advance(utf8.RuneError, skip)
+			continue
+		}
+
+		stream.off += uint(size)
+		stream.setCur(ch, size, pos, nil)
+		return true
 	}
-	stream.curr = &stream.buf[stream.spec]
-	stream.spec++
-	return stream.curr.err == nil
 }
 
 // Rune returns the character at the current stream position.
 func (stream *RuneStream) Rune() rune {
-	return stream.curr.value
+	return stream.curRune
 }
 
 // Size returns the number of bytes occupied by the character at the current
 // stream position.
 func (stream *RuneStream) Size() int {
-	return stream.curr.size
+	return stream.curSize
 }
 
 // Position returns the position of the stream.
 func (stream *RuneStream) Position() Position {
-	return stream.curr.pos
+	return stream.curPos
 }
 
 // Err returns the I/O error encountered while reading the stream.
 func (stream *RuneStream) Err() error {
-	return stream.curr.err
+	return stream.curErr
 }
 
 // Take consumes one character, advancing the stream only if the next rune
 // matches pred.
 func (stream *RuneStream) Take(pred func(rune) bool) (rune, bool) {
 	sp := stream.Save()
-	if stream.Advance() && pred(stream.curr.value) {
-		return stream.curr.value, true
+	if stream.Advance() && pred(stream.curRune) {
+		return stream.curRune, true
 	}
 	stream.Restore(sp)
 	return 0, false
@@ -254,11 +462,11 @@ func (stream *RuneStream) TakeWhile(max int, out []rune, pred func(rune) bool) [
 		if !stream.Advance() {
 			break
 		}
-		if !pred(stream.curr.value) {
+		if !pred(stream.curRune) {
 			break
 		}
 		count++
-		out = append(out, stream.curr.value)
+		out = append(out, stream.curRune)
 		sp = stream.Save()
 	}
 	stream.Restore(sp)
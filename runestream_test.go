@@ -266,6 +266,37 @@ func (r *ZeroReader) Read(p []byte) (int, error) {
 	return n, nil
 }
 
+func TestRuneStream_Rewind(t *testing.T) {
+	var o Options
+	var stream RuneStream
+
+	stream.Init(strings.NewReader("abc"), o)
+	stream.Advance()
+	stream.Advance()
+	stream.Rewind()
+
+	if !stream.Advance() || stream.Rune() != 'a' {
+		t.Fatalf("expected Rewind to return to 'a', got %q", stream.Rune())
+	}
+}
+
+func TestRuneStream_StaleSavePointPanics(t *testing.T) {
+	var o Options
+	var stream RuneStream
+
+	stream.Init(strings.NewReader("abc"), o)
+	stream.Advance()
+	sp := stream.Save()
+	stream.Commit()
+
+	defer func() {
+		if recover() == nil {
+			t.Errorf("expected Restore to panic on a SavePoint invalidated by Commit")
+		}
+	}()
+	stream.Restore(sp)
+}
+
 func BenchmarkRuneStream_advance(b *testing.B) {
 	r := new(ZeroReader)
 	stream := NewRuneStream(r)
@@ -0,0 +1,49 @@
+package runestream
+
+// StartSegment begins recording a literal segment at the stream's current
+// position.  Every byte consumed by Advance() between StartSegment and the
+// matching EndSegment (or Segment) is included in the returned segment.
+//
+// Only one segment can be active at a time; a second StartSegment call
+// discards the previous segment's start.
+func (stream *RuneStream) StartSegment() {
+	stream.segActive = true
+	stream.segStart = stream.off
+	stream.lit = stream.lit[:0]
+}
+
+// EndSegment stops recording the active segment and returns the bytes
+// spanned by it, i.e. every byte consumed by Advance() since the matching
+// StartSegment.
+//
+// If no Commit() occurred during the segment, the returned slice aliases
+// the stream's internal buffer and is only valid until the next call that
+// mutates it (Advance, Commit, Restore, Rewind, ...); copy it if it needs to
+// outlive that. If one or more Commit() calls occurred, the bytes they slid
+// out of the buffer were already copied into a scratch buffer, so the
+// returned slice is a fresh copy.
+func (stream *RuneStream) EndSegment() []byte {
+	if !stream.segActive {
+		panic("EndSegment called without a matching StartSegment")
+	}
+	stream.segActive = false
+	tail := stream.buf[stream.segStart:stream.off]
+	if len(stream.lit) == 0 {
+		return tail
+	}
+	out := make([]byte, len(stream.lit)+len(tail))
+	n := copy(out, stream.lit)
+	copy(out[n:], tail)
+	return out
+}
+
+// Segment is a convenience wrapper around EndSegment that converts the
+// segment to a string, e.g.:
+//
+//	s.StartSegment()
+//	s.TakeWhile(-1, nil, unicode.IsLetter)
+//	word := s.Segment()
+//
+func (stream *RuneStream) Segment() string {
+	return string(stream.EndSegment())
+}
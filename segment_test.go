@@ -0,0 +1,48 @@
+package runestream
+
+import (
+	"strings"
+	"testing"
+	"unicode"
+)
+
+func TestRuneStream_Segment(t *testing.T) {
+	var o Options
+	var stream RuneStream
+
+	stream.Init(strings.NewReader("hello world"), o)
+	stream.StartSegment()
+	stream.TakeWhile(-1, nil, unicode.IsLetter)
+	word := stream.Segment()
+	if word != "hello" {
+		t.Errorf("expected %q, got %q", "hello", word)
+	}
+	stream.Commit()
+}
+
+func TestRuneStream_Segment_AcrossCommit(t *testing.T) {
+	var o Options
+	var stream RuneStream
+
+	stream.Init(strings.NewReader("abcdef"), o)
+	stream.StartSegment()
+	stream.Advance()
+	stream.Commit() // slides 'a' out of buf mid-segment
+	stream.Advance()
+	stream.Advance()
+	word := stream.Segment()
+	if word != "abc" {
+		t.Errorf("expected %q, got %q", "abc", word)
+	}
+}
+
+func TestRuneStream_EndSegment_PanicsWithoutStart(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Errorf("expected a panic calling EndSegment without StartSegment")
+		}
+	}()
+	var stream RuneStream
+	stream.Init(strings.NewReader(""), Options{})
+	stream.EndSegment()
+}